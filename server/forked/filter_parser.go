@@ -0,0 +1,443 @@
+package forked
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Expr is a parsed node of a content search filter. Filters use a small
+// CEL-like expression syntax: boolean combinators (&&, ||, !), parentheses,
+// and method-call predicates such as content.contains("foo").
+type Expr interface {
+	isExpr()
+}
+
+// AndExpr is the conjunction of two expressions (&&).
+type AndExpr struct {
+	Left, Right Expr
+}
+
+// OrExpr is the disjunction of two expressions (||).
+type OrExpr struct {
+	Left, Right Expr
+}
+
+// NotExpr negates the inner expression (!).
+type NotExpr struct {
+	Inner Expr
+}
+
+// CallExpr is a method-call predicate, e.g. content.contains("foo").
+// Object is the receiver identifier (almost always "content"), Method is the
+// called method name, and Args holds the string-literal arguments in order.
+//
+// Supported content methods are contains, notContains, startsWith,
+// endsWith, and matches. contains/notContains/startsWith/endsWith accept an
+// optional second argument "i" to match case-insensitively, e.g.
+// content.contains("Foo", "i"). matches takes a `/regex/flags` literal,
+// e.g. content.matches("/^TODO/i").
+type CallExpr struct {
+	Object string
+	Method string
+	Args   []string
+}
+
+// UnknownExpr wraps a clause the parser could not interpret as a content
+// predicate, e.g. `creator_id == 1` or `visibility in ["PUBLIC"]`. These
+// clauses are about fields other than memo content, so Eval treats them as
+// "unknown" and resolves them to true rather than failing the whole filter.
+type UnknownExpr struct {
+	Raw string
+}
+
+func (*AndExpr) isExpr()     {}
+func (*OrExpr) isExpr()      {}
+func (*NotExpr) isExpr()     {}
+func (*CallExpr) isExpr()    {}
+func (*UnknownExpr) isExpr() {}
+
+// tokenKind identifies the lexical class of a filterToken.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+	tokOther // any other run of characters, kept verbatim for UnknownExpr
+)
+
+type filterToken struct {
+	kind tokenKind
+	text string
+}
+
+// tokenizeFilter splits a filter string into filterTokens. Identifiers may
+// contain dots (content.contains), string literals are double-quoted, and
+// everything else that isn't whitespace, a combinator, or a parenthesis is
+// collapsed into tokOther runs so unrecognized clauses can be reassembled
+// verbatim for UnknownExpr.
+func tokenizeFilter(s string) []filterToken {
+	var tokens []filterToken
+	i := 0
+	n := len(s)
+	for i < n {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, filterToken{tokLParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, filterToken{tokRParen, ")"})
+			i++
+		case c == '&' && i+1 < n && s[i+1] == '&':
+			tokens = append(tokens, filterToken{tokAnd, "&&"})
+			i += 2
+		case c == '|' && i+1 < n && s[i+1] == '|':
+			tokens = append(tokens, filterToken{tokOr, "||"})
+			i += 2
+		case c == '!' && !(i+1 < n && s[i+1] == '='):
+			tokens = append(tokens, filterToken{tokNot, "!"})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < n && s[j] != '"' {
+				if s[j] == '\\' && j+1 < n {
+					j++
+				}
+				j++
+			}
+			end := j
+			if end < n {
+				end++ // include closing quote
+			}
+			tokens = append(tokens, filterToken{tokString, s[i:end]})
+			i = end
+		case isIdentStart(c):
+			j := i
+			for j < n && isIdentPart(s[j]) {
+				j++
+			}
+			tokens = append(tokens, filterToken{tokIdent, s[i:j]})
+			i = j
+		default:
+			j := i
+			for j < n {
+				cj := s[j]
+				if cj == ' ' || cj == '\t' || cj == '\n' || cj == '\r' ||
+					cj == '(' || cj == ')' || cj == '"' || isIdentStart(cj) ||
+					(cj == '&' && j+1 < n && s[j+1] == '&') ||
+					(cj == '|' && j+1 < n && s[j+1] == '|') ||
+					(cj == '!' && !(j+1 < n && s[j+1] == '=')) {
+					break
+				}
+				j++
+			}
+			if j == i {
+				j++ // always make progress
+			}
+			tokens = append(tokens, filterToken{tokOther, s[i:j]})
+			i = j
+		}
+	}
+	tokens = append(tokens, filterToken{tokEOF, ""})
+	return tokens
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9') || c == '.' || c == '_'
+}
+
+// filterParser turns a token stream into an Expr tree using standard
+// recursive-descent precedence: || binds loosest, then &&, then unary !.
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+// ParseFilter parses a single filter string (one element of the `filters`
+// slice accepted by ProcessContentSearchFilter) into an Expr tree. Clauses
+// the parser does not recognize as content predicates are preserved as
+// UnknownExpr rather than rejected, since a filter mixes content predicates
+// with unrelated fields (creator_id, visibility, ...).
+func ParseFilter(filter string) (Expr, error) {
+	p := &filterParser{tokens: tokenizeFilter(filter)}
+	expr := p.parseOr()
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("forked: unexpected token %q in filter %q", p.peek().text, filter)
+	}
+	return expr, nil
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	t := p.tokens[p.pos]
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) parseOr() Expr {
+	left := p.parseAnd()
+	for p.peek().kind == tokOr {
+		p.next()
+		right := p.parseAnd()
+		left = &OrExpr{Left: left, Right: right}
+	}
+	return left
+}
+
+func (p *filterParser) parseAnd() Expr {
+	left := p.parseUnary()
+	for p.peek().kind == tokAnd {
+		p.next()
+		right := p.parseUnary()
+		left = &AndExpr{Left: left, Right: right}
+	}
+	return left
+}
+
+func (p *filterParser) parseUnary() Expr {
+	if p.peek().kind == tokNot {
+		p.next()
+		return &NotExpr{Inner: p.parseUnary()}
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() Expr {
+	if p.peek().kind == tokLParen {
+		p.next()
+		inner := p.parseOr()
+		if p.peek().kind == tokRParen {
+			p.next()
+		}
+		return inner
+	}
+
+	if call, ok := p.tryParseCall(); ok {
+		return call
+	}
+
+	return p.parseUnknownClause()
+}
+
+// tryParseCall attempts to parse an `ident.method("arg1", "arg2")` call
+// starting at the current position, without consuming tokens on failure.
+// A leading ! is not handled here; parseUnary consumes it first.
+func (p *filterParser) tryParseCall() (*CallExpr, bool) {
+	start := p.pos
+	if p.peek().kind != tokIdent {
+		return nil, false
+	}
+	ident := p.peek().text
+	object, method, hasDot := strings.Cut(ident, ".")
+	if !hasDot {
+		p.pos = start
+		return nil, false
+	}
+	p.next() // consume ident
+
+	if p.peek().kind != tokLParen {
+		p.pos = start
+		return nil, false
+	}
+	p.next() // consume (
+
+	var args []string
+	for p.peek().kind != tokRParen && p.peek().kind != tokEOF {
+		tok := p.next()
+		if tok.kind == tokString {
+			args = append(args, unquoteFilterString(tok.text))
+		} else if tok.kind == tokOther && tok.text == "," {
+			continue
+		}
+	}
+	if p.peek().kind != tokRParen {
+		p.pos = start
+		return nil, false
+	}
+	p.next() // consume )
+
+	return &CallExpr{Object: object, Method: method, Args: args}, true
+}
+
+// parseUnknownClause consumes tokens up to the next top-level &&, ||, or )
+// and returns them joined back together as an UnknownExpr.
+func (p *filterParser) parseUnknownClause() Expr {
+	var parts []string
+	depth := 0
+	for {
+		tok := p.peek()
+		if tok.kind == tokEOF {
+			break
+		}
+		if depth == 0 && (tok.kind == tokAnd || tok.kind == tokOr) {
+			break
+		}
+		if tok.kind == tokRParen {
+			if depth == 0 {
+				break
+			}
+			depth--
+		}
+		if tok.kind == tokLParen {
+			depth++
+		}
+		parts = append(parts, tok.text)
+		p.next()
+	}
+	return &UnknownExpr{Raw: strings.Join(parts, " ")}
+}
+
+func unquoteFilterString(s string) string {
+	s = strings.TrimPrefix(s, `"`)
+	s = strings.TrimSuffix(s, `"`)
+	return strings.ReplaceAll(s, `\"`, `"`)
+}
+
+// Eval evaluates a parsed filter Expr against the visible (placeholder
+// stripped) content of a memo. vars carries extra context a CallExpr
+// evaluator may need (currently unused, reserved for future operators).
+// UnknownExpr clauses - predicates about fields other than content, e.g.
+// creator_id or visibility - are treated as unknown and resolve to true so
+// they never cause an otherwise-matching content filter to fail.
+func Eval(expr Expr, content string, vars map[string]string) bool {
+	switch e := expr.(type) {
+	case *AndExpr:
+		return Eval(e.Left, content, vars) && Eval(e.Right, content, vars)
+	case *OrExpr:
+		return Eval(e.Left, content, vars) || Eval(e.Right, content, vars)
+	case *NotExpr:
+		return !Eval(e.Inner, content, vars)
+	case *CallExpr:
+		return evalCall(e, content)
+	case *UnknownExpr:
+		return true
+	default:
+		return true
+	}
+}
+
+// evalCall evaluates a single content.* predicate against visible content.
+func evalCall(call *CallExpr, content string) bool {
+	if call.Object != "content" || len(call.Args) == 0 {
+		return true
+	}
+	switch call.Method {
+	case "contains":
+		return matchSubstring(content, call.Args, strings.Contains)
+	case "notContains":
+		return !matchSubstring(content, call.Args, strings.Contains)
+	case "startsWith":
+		return matchSubstring(content, call.Args, strings.HasPrefix)
+	case "endsWith":
+		return matchSubstring(content, call.Args, strings.HasSuffix)
+	case "matches":
+		return evalMatches(content, call.Args[0])
+	default:
+		return true
+	}
+}
+
+// matchSubstring applies cmp (strings.Contains, strings.HasPrefix, ...)
+// between content and args[0], lower-casing both sides first when a second
+// argument "i" requests a case-insensitive comparison.
+func matchSubstring(content string, args []string, cmp func(s, substr string) bool) bool {
+	needle := args[0]
+	haystack := content
+	if len(args) > 1 && strings.EqualFold(args[1], "i") {
+		haystack = strings.ToLower(haystack)
+		needle = strings.ToLower(needle)
+	}
+	return cmp(haystack, needle)
+}
+
+// regexCache holds compiled regexes keyed by their original `/regex/flags`
+// literal, so a filter reused across many memos only pays compilation once.
+var regexCache sync.Map // map[string]*regexp.Regexp
+
+// evalMatches evaluates content.matches("/regex/flags") against content.
+// A literal that fails to compile never matches, rather than erroring the
+// whole filter.
+func evalMatches(content string, literal string) bool {
+	re, err := compileCachedRegex(literal)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(content)
+}
+
+func compileCachedRegex(literal string) (*regexp.Regexp, error) {
+	if cached, ok := regexCache.Load(literal); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	pattern, flags := splitRegexLiteral(literal)
+	if strings.Contains(flags, "i") {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(literal, re)
+	return re, nil
+}
+
+// splitRegexLiteral splits a `/regex/flags` literal into its pattern and
+// flags. A literal without a leading slash is treated as a bare pattern
+// with no flags.
+func splitRegexLiteral(literal string) (pattern, flags string) {
+	if len(literal) >= 2 && literal[0] == '/' {
+		if idx := strings.LastIndex(literal[1:], "/"); idx >= 0 {
+			idx++ // rebase to an index into literal rather than literal[1:]
+			return literal[1:idx], literal[idx+1:]
+		}
+	}
+	return literal, ""
+}
+
+// ProcessContentSearchFilter processes content search filters and checks if
+// the memo content matches them, excluding matches within hidden content
+// placeholders.
+//
+// This is a thin backward-compatible wrapper around ParseFilter/Eval: each
+// filter string is parsed into an Expr tree (so `||`, `!`, and parentheses
+// are honored instead of every content.contains() literal being ANDed
+// together) and evaluated against the placeholder-stripped content. All
+// filters in the slice are ANDed together, matching the previous behavior.
+func ProcessContentSearchFilter(content string, filters []string) bool {
+	visibleContent := removeHiddenPlaceholders(content)
+
+	for _, filter := range filters {
+		expr, err := ParseFilter(filter)
+		if err != nil {
+			// A malformed filter can't be evaluated as a content predicate;
+			// treat it like one with no content.contains() clauses.
+			continue
+		}
+		if !Eval(expr, visibleContent, nil) {
+			return false
+		}
+	}
+
+	return true
+}
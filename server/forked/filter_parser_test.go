@@ -0,0 +1,202 @@
+package forked
+
+import (
+	"testing"
+)
+
+func TestParseFilterEval(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		filter   string
+		expected bool
+	}{
+		{
+			name:     "single contains true",
+			content:  "Hello world",
+			filter:   `content.contains("Hello")`,
+			expected: true,
+		},
+		{
+			name:     "single contains false",
+			content:  "Hello world",
+			filter:   `content.contains("goodbye")`,
+			expected: false,
+		},
+		{
+			name:     "and requires both",
+			content:  "Hello world",
+			filter:   `content.contains("Hello") && content.contains("universe")`,
+			expected: false,
+		},
+		{
+			name:     "or requires only one",
+			content:  "Hello world",
+			filter:   `content.contains("foo") || content.contains("world")`,
+			expected: true,
+		},
+		{
+			name:     "negation",
+			content:  "Hello world",
+			filter:   `!content.contains("goodbye")`,
+			expected: true,
+		},
+		{
+			name:     "parenthesized or inside and",
+			content:  "Hello world",
+			filter:   `(content.contains("foo") || content.contains("Hello")) && content.contains("world")`,
+			expected: true,
+		},
+		{
+			name:     "unrelated predicate is ignored",
+			content:  "Hello world",
+			filter:   `creator_id == 1 && content.contains("Hello")`,
+			expected: true,
+		},
+		{
+			name:     "unrelated predicate alone matches",
+			content:  "Hello world",
+			filter:   `visibility in ["PUBLIC", "PROTECTED"]`,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("ParseFilter() error = %v", err)
+			}
+			result := Eval(expr, tt.content, nil)
+			if result != tt.expected {
+				t.Errorf("Eval() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseFilterOperators(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		filter   string
+		expected bool
+	}{
+		{
+			name:     "startsWith true",
+			content:  "TODO buy milk",
+			filter:   `content.startsWith("TODO")`,
+			expected: true,
+		},
+		{
+			name:     "startsWith false",
+			content:  "buy milk TODO",
+			filter:   `content.startsWith("TODO")`,
+			expected: false,
+		},
+		{
+			name:     "endsWith true",
+			content:  "buy milk done",
+			filter:   `content.endsWith("done")`,
+			expected: true,
+		},
+		{
+			name:     "notContains true when absent",
+			content:  "TODO buy milk",
+			filter:   `content.notContains("done")`,
+			expected: true,
+		},
+		{
+			name:     "notContains false when present",
+			content:  "TODO buy milk done",
+			filter:   `content.notContains("done")`,
+			expected: false,
+		},
+		{
+			name:     "negated contains",
+			content:  "TODO buy milk",
+			filter:   `!content.contains("done")`,
+			expected: true,
+		},
+		{
+			name:     "case insensitive contains",
+			content:  "Hello World",
+			filter:   `content.contains("hello", "i")`,
+			expected: true,
+		},
+		{
+			name:     "case sensitive contains by default",
+			content:  "Hello World",
+			filter:   `content.contains("hello")`,
+			expected: false,
+		},
+		{
+			name:     "matches regex",
+			content:  "TODO buy milk",
+			filter:   `content.matches("/^TODO/")`,
+			expected: true,
+		},
+		{
+			name:     "matches regex with case-insensitive flag",
+			content:  "todo buy milk",
+			filter:   `content.matches("/^TODO/i")`,
+			expected: true,
+		},
+		{
+			name:     "combined starts-with and not-contains",
+			content:  "TODO buy milk",
+			filter:   `content.startsWith("TODO") && content.notContains("done")`,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("ParseFilter() error = %v", err)
+			}
+			result := Eval(expr, tt.content, nil)
+			if result != tt.expected {
+				t.Errorf("Eval() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessContentSearchFilterBooleanLogic(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		filters  []string
+		expected bool
+	}{
+		{
+			name:     "or is no longer treated as and",
+			content:  "foo only",
+			filters:  []string{`content.contains("foo") || content.contains("bar")`},
+			expected: true,
+		},
+		{
+			name:     "or fails when neither side matches",
+			content:  "baz only",
+			filters:  []string{`content.contains("foo") || content.contains("bar")`},
+			expected: false,
+		},
+		{
+			name:     "negation honored",
+			content:  "foo only",
+			filters:  []string{`!content.contains("bar")`},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ProcessContentSearchFilter(tt.content, tt.filters)
+			if result != tt.expected {
+				t.Errorf("ProcessContentSearchFilter() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
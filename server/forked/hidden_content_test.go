@@ -30,6 +30,11 @@ func TestProcessHiddenContent(t *testing.T) {
 			input:    ":::hide{level=high;type=sensitive}\nSecret line 1\nSecret line 2\n:::",
 			expected: "[hide-block{level:high,type:sensitive}]",
 		},
+		{
+			name:     "attributes keep source order, not alphabetical order",
+			input:    ":::hide{foo=foo;bar=bar}\nSecret\n:::",
+			expected: "[hide-block{foo:foo,bar:bar}]",
+		},
 		{
 			name:     "mixed inline and block",
 			input:    "Start :::hide inline::: middle\n:::hide{foo=bar}\nblock content\n:::\nend",
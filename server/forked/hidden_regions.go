@@ -0,0 +1,241 @@
+package forked
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Match an already-rendered hidden content placeholder, e.g. [hide-inline]
+// or [hide-block{level:high}]. These appear once ProcessHiddenContent has
+// already collapsed a :::hide::: region and its original body is gone.
+var (
+	inlinePlaceholderRe = regexp.MustCompile(`\[hide-inline(?:\{([^}]*)\})?\]`)
+	blockPlaceholderRe  = regexp.MustCompile(`\[hide-block(?:\{([^}]*)\})?\]`)
+)
+
+// HiddenRegion describes one hidden span found in memo content, whether it
+// is still a raw `:::hide{...}:::` marker or an already-rendered
+// `[hide-*{...}]` placeholder.
+type HiddenRegion struct {
+	// Offset is the byte offset of the region's start within the content
+	// it was parsed from.
+	Offset int
+	// Length is the byte length of the full matched span (markers/brackets
+	// included), so callers can compute content[Offset : Offset+Length].
+	Length int
+	// Kind is "block" or "inline" for a raw :::hide::: marker, or
+	// "placeholder" for an already-rendered [hide-*] marker.
+	Kind string
+	// Attrs is the parsed `key=value` attribute list (or, for placeholder
+	// regions, the rendered `key:value` list). Empty if no attributes.
+	Attrs map[string]string
+	// Raw is the original hidden body text. Empty for placeholder regions,
+	// since the body was already discarded when the placeholder was
+	// rendered.
+	Raw string
+}
+
+// ParseHiddenRegions scans content for hidden spans - both raw :::hide:::
+// markers (via tokenizeHideContent, so fences, code spans, and nested
+// blocks are handled correctly) and already-rendered [hide-*] placeholders
+// - and returns them ordered by Offset. Downstream code (search filtering,
+// export, rendering) can use the returned regions instead of re-parsing
+// hide markers itself.
+func ParseHiddenRegions(content string) []HiddenRegion {
+	var regions []HiddenRegion
+
+	for _, tok := range tokenizeHideContent(content) {
+		if tok.Kind == "text" {
+			continue
+		}
+		regions = append(regions, HiddenRegion{
+			Offset: tok.Offset,
+			Length: tok.Length,
+			Kind:   tok.Kind,
+			Attrs:  tok.Attrs,
+			Raw:    tok.Raw,
+		})
+	}
+
+	for _, m := range blockPlaceholderRe.FindAllStringSubmatchIndex(content, -1) {
+		regions = append(regions, HiddenRegion{
+			Offset: m[0],
+			Length: m[1] - m[0],
+			Kind:   "placeholder",
+			Attrs:  parsePlaceholderAttrs(submatchString(content, m, 1)),
+		})
+	}
+	for _, m := range inlinePlaceholderRe.FindAllStringSubmatchIndex(content, -1) {
+		regions = append(regions, HiddenRegion{
+			Offset: m[0],
+			Length: m[1] - m[0],
+			Kind:   "placeholder",
+			Attrs:  parsePlaceholderAttrs(submatchString(content, m, 1)),
+		})
+	}
+
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Offset < regions[j].Offset })
+	return dedupeOverlappingRegions(regions)
+}
+
+// dedupeOverlappingRegions drops any region that starts before the end of
+// the previously kept region (regions are sorted by Offset), whether it is
+// fully contained in it or only partly overlaps it, so a nested or
+// re-matched span isn't excised twice.
+func dedupeOverlappingRegions(regions []HiddenRegion) []HiddenRegion {
+	var out []HiddenRegion
+	end := -1
+	for _, r := range regions {
+		if r.Offset < end {
+			continue
+		}
+		out = append(out, r)
+		end = r.Offset + r.Length
+	}
+	return out
+}
+
+// submatchString returns submatch group i of an index match produced by
+// FindAllStringSubmatchIndex, or "" if the group did not participate.
+func submatchString(content string, m []int, i int) string {
+	start, end := m[2*i], m[2*i+1]
+	if start < 0 || end < 0 {
+		return ""
+	}
+	return content[start:end]
+}
+
+// parsePlaceholderAttrs parses an already-rendered placeholder's attribute
+// list, e.g. "level:high,type:sensitive", into a map.
+func parsePlaceholderAttrs(raw string) map[string]string {
+	attrs := make(map[string]string)
+	if raw == "" {
+		return attrs
+	}
+	for _, attr := range strings.Split(raw, ",") {
+		kv := strings.SplitN(attr, ":", 2)
+		if len(kv) == 2 {
+			attrs[kv[0]] = kv[1]
+		}
+	}
+	return attrs
+}
+
+// excludeHiddenRegions removes each region's span from content, as if it
+// had never been there.
+func excludeHiddenRegions(content string, regions []HiddenRegion) string {
+	if len(regions) == 0 {
+		return content
+	}
+	var b strings.Builder
+	last := 0
+	for _, r := range regions {
+		if r.Offset < last {
+			continue
+		}
+		b.WriteString(content[last:r.Offset])
+		last = r.Offset + r.Length
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// clearanceRank orders the clearance levels a :::hide::: block's `level`
+// attribute is expected to use. An unrecognized level is treated as
+// unreachable, so unknown levels default to staying hidden.
+var clearanceRank = map[string]int{"low": 1, "medium": 2, "high": 3}
+
+// VisibilityContext carries the information needed to decide whether a
+// particular viewer may see a hidden region's original content.
+type VisibilityContext struct {
+	// Role is the viewer's role, compared against a block's `role`
+	// attribute (case-insensitively).
+	Role string
+	// ClearanceLevel is the viewer's clearance, compared against a block's
+	// `level` attribute using clearanceRank.
+	ClearanceLevel string
+	// Tags are labels granted to the viewer, compared against a block's
+	// `tags` attribute (a comma-separated list): the viewer must carry at
+	// least one of the listed tags.
+	Tags []string
+	// Redaction, if non-empty, replaces a region's body when access is
+	// denied, instead of falling back to the default [hide-*] placeholder.
+	Redaction string
+	// Predicate, if set, overrides the default role/level/tags evaluation
+	// entirely and decides access from the block's raw attrs map.
+	Predicate func(attrs map[string]string) bool
+}
+
+// canView reports whether ctx grants access to a region with the given
+// attributes. Access defaults to denied: a block with none of `role`,
+// `level`, or `tags` set (and no Predicate) grants no one access, rather
+// than being treated as unrestricted.
+func (ctx VisibilityContext) canView(attrs map[string]string) bool {
+	if ctx.Predicate != nil {
+		return ctx.Predicate(attrs)
+	}
+	role, hasRole := attrs["role"]
+	level, hasLevel := attrs["level"]
+	tagList, hasTags := attrs["tags"]
+	if !hasRole && !hasLevel && !hasTags {
+		return false
+	}
+	if hasRole && role != "" && !strings.EqualFold(role, ctx.Role) {
+		return false
+	}
+	if hasLevel && level != "" && !ctx.meetsClearance(level) {
+		return false
+	}
+	if hasTags && tagList != "" && !ctx.hasAnyTag(tagList) {
+		return false
+	}
+	return true
+}
+
+func (ctx VisibilityContext) meetsClearance(required string) bool {
+	requiredRank, ok := clearanceRank[strings.ToLower(required)]
+	if !ok {
+		return false
+	}
+	return clearanceRank[strings.ToLower(ctx.ClearanceLevel)] >= requiredRank
+}
+
+func (ctx VisibilityContext) hasAnyTag(tagList string) bool {
+	for _, required := range strings.Split(tagList, ",") {
+		required = strings.TrimSpace(required)
+		for _, tag := range ctx.Tags {
+			if strings.EqualFold(required, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RenderHiddenContent evaluates every raw :::hide::: region in content
+// against ctx and either keeps the original body (viewer is authorized),
+// replaces it with ctx.Redaction (viewer is denied and a custom redaction
+// was supplied), or falls back to the standard [hide-*{...}] placeholder.
+//
+// Unlike ProcessHiddenContent, which always collapses hidden content, this
+// lets callers reveal a block to one viewer and redact it for another from
+// the same stored content. Content is walked with tokenizeHideContent, so
+// fenced code, inline code spans, and nested :::hide blocks are respected
+// the same way ProcessHiddenContent respects them.
+func RenderHiddenContent(content string, ctx VisibilityContext) string {
+	var b strings.Builder
+	for _, tok := range tokenizeHideContent(content) {
+		switch {
+		case tok.Kind == "text":
+			b.WriteString(tok.Raw)
+		case ctx.canView(tok.Attrs):
+			b.WriteString(tok.Raw)
+		case ctx.Redaction != "":
+			b.WriteString(ctx.Redaction)
+		default:
+			b.WriteString(formatHidePlaceholder(tok.Kind, tok.Attrs, tok.AttrOrder))
+		}
+	}
+	return b.String()
+}
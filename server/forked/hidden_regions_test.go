@@ -0,0 +1,99 @@
+package forked
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHiddenRegions(t *testing.T) {
+	content := "Start :::hide{level=high;role=admin} secret::: end"
+	regions := ParseHiddenRegions(content)
+	if len(regions) != 1 {
+		t.Fatalf("ParseHiddenRegions() returned %d regions, want 1", len(regions))
+	}
+
+	r := regions[0]
+	if r.Kind != "inline" {
+		t.Errorf("Kind = %q, want %q", r.Kind, "inline")
+	}
+	if r.Raw != "secret" {
+		t.Errorf("Raw = %q, want %q", r.Raw, "secret")
+	}
+	wantAttrs := map[string]string{"level": "high", "role": "admin"}
+	if !reflect.DeepEqual(r.Attrs, wantAttrs) {
+		t.Errorf("Attrs = %v, want %v", r.Attrs, wantAttrs)
+	}
+	if content[r.Offset:r.Offset+r.Length] != `:::hide{level=high;role=admin} secret:::` {
+		t.Errorf("region span = %q, want the full marker text", content[r.Offset:r.Offset+r.Length])
+	}
+}
+
+func TestParseHiddenRegionsPlaceholder(t *testing.T) {
+	content := "Public [hide-block{level:high}] text"
+	regions := ParseHiddenRegions(content)
+	if len(regions) != 1 {
+		t.Fatalf("ParseHiddenRegions() returned %d regions, want 1", len(regions))
+	}
+	if regions[0].Kind != "placeholder" {
+		t.Errorf("Kind = %q, want %q", regions[0].Kind, "placeholder")
+	}
+	if regions[0].Raw != "" {
+		t.Errorf("Raw = %q, want empty (placeholder body already discarded)", regions[0].Raw)
+	}
+}
+
+func TestRenderHiddenContent(t *testing.T) {
+	content := "Public intro :::hide{level=high;role=admin} classified data ::: public outro"
+
+	tests := []struct {
+		name     string
+		ctx      VisibilityContext
+		expected string
+	}{
+		{
+			name:     "authorized viewer sees the body",
+			ctx:      VisibilityContext{Role: "admin", ClearanceLevel: "high"},
+			expected: "Public intro classified data  public outro",
+		},
+		{
+			name:     "wrong role falls back to placeholder",
+			ctx:      VisibilityContext{Role: "member", ClearanceLevel: "high"},
+			expected: "Public intro [hide-inline{level:high,role:admin}] public outro",
+		},
+		{
+			name:     "insufficient clearance falls back to placeholder",
+			ctx:      VisibilityContext{Role: "admin", ClearanceLevel: "low"},
+			expected: "Public intro [hide-inline{level:high,role:admin}] public outro",
+		},
+		{
+			name:     "denied viewer with custom redaction",
+			ctx:      VisibilityContext{Role: "member", ClearanceLevel: "low", Redaction: "[redacted]"},
+			expected: "Public intro [redacted] public outro",
+		},
+		{
+			name: "predicate overrides role/level checks",
+			ctx: VisibilityContext{
+				Predicate: func(attrs map[string]string) bool { return attrs["level"] == "high" },
+			},
+			expected: "Public intro classified data  public outro",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := RenderHiddenContent(content, tt.ctx)
+			if result != tt.expected {
+				t.Errorf("RenderHiddenContent() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderHiddenContentNoAttrsDefaultsToDenied(t *testing.T) {
+	content := ":::hide\nsecret data\n:::"
+	ctx := VisibilityContext{Role: "guest", ClearanceLevel: "low"}
+	result := RenderHiddenContent(content, ctx)
+	if result != "[hide-block]" {
+		t.Errorf("RenderHiddenContent() = %q, want %q (a block with no role/level/tags attrs must deny by default)", result, "[hide-block]")
+	}
+}
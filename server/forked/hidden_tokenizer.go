@@ -0,0 +1,243 @@
+package forked
+
+import (
+	"regexp"
+	"strings"
+)
+
+// hideToken is one piece of content produced by tokenizeHideContent: either
+// a verbatim passthrough span ("text") or a hidden region ("block" /
+// "inline"). Concatenating every token's Raw in order reproduces content
+// exactly when no hiding is applied.
+type hideToken struct {
+	Kind   string // "text", "block", or "inline"
+	Raw    string // passthrough text, or the hidden body (markers stripped)
+	Attrs  map[string]string
+	// AttrOrder is the attribute keys in the order they appeared in the
+	// source `key=value;...` list, so placeholder rendering can reproduce
+	// that order instead of Attrs' unspecified map iteration order.
+	AttrOrder []string
+	Offset    int // byte offset of the full original span (markers included) in content
+	Length    int // byte length of the full original span (markers included) in content
+}
+
+// inlineCodeSpanRe matches a single-line inline code span, so hide-like
+// syntax inside it is left untouched rather than parsed.
+var inlineCodeSpanRe = regexp.MustCompile("`[^`]*`")
+
+// inlineHidePatternRe matches a `:::hide{attrs} body:::` run within a
+// single line.
+var inlineHidePatternRe = regexp.MustCompile(`:::hide(?:\{([^}]*)\})?\s+(.*?):::`)
+
+// blockOpenerLineRe matches a `:::hide` / `:::hide{attrs}` marker that runs
+// to the end of the line, wherever on the line it starts. Like the baseline
+// regex parser this replaced, arbitrary text may precede the marker on the
+// same line (e.g. "note: :::hide\nsecret\n:::") - only a newline immediately
+// after the marker (optional attrs included) opens a block.
+var blockOpenerLineRe = regexp.MustCompile(`:::hide(?:\{([^}]*)\})?$`)
+
+// tokenizeHideContent walks content one line at a time and emits hideTokens,
+// tracking fenced code blocks (``` / ~~~) and inline code spans (`...`) so
+// stray ::: markers inside them are never mistaken for hide syntax, and
+// counting :::hide block nesting depth so a block containing its own
+// nested :::hide block is only closed by its matching ":::" line.
+func tokenizeHideContent(content string) []hideToken {
+	lines := splitRawLines(content)
+
+	var tokens []hideToken
+
+	fenceOpen := false
+	var fenceChar byte
+	var fenceLen int
+
+	hideDepth := 0
+	var blockStart int
+	var blockAttrs map[string]string
+	var blockAttrOrder []string
+	var bodyLines []string
+
+	for _, ln := range lines {
+		trimmed := strings.TrimSpace(ln.text)
+
+		if fenceOpen {
+			if ch, length, ok := detectFenceMarker(trimmed); ok && ch == fenceChar && length >= fenceLen {
+				fenceOpen = false
+			}
+			tokens = appendContentLine(tokens, ln, hideDepth, &bodyLines)
+			continue
+		}
+
+		if ch, length, ok := detectFenceMarker(trimmed); ok {
+			fenceOpen = true
+			fenceChar = ch
+			fenceLen = length
+			tokens = appendContentLine(tokens, ln, hideDepth, &bodyLines)
+			continue
+		}
+
+		if hideDepth == 0 {
+			if m := blockOpenerLineRe.FindStringSubmatchIndex(ln.text); m != nil {
+				prefixLen := m[0]
+				if prefixLen > 0 {
+					tokens = append(tokens, hideToken{Kind: "text", Raw: ln.text[:prefixLen], Offset: ln.start, Length: prefixLen})
+				}
+				blockStart = ln.start + prefixLen
+				blockAttrs, blockAttrOrder = parseHideAttrs(submatchString(ln.text, m, 1))
+				bodyLines = nil
+				hideDepth = 1
+				continue
+			}
+			tokens = append(tokens, scanInlineLine(ln)...)
+			continue
+		}
+
+		// hideDepth > 0: inside a (possibly nested) block.
+		if trimmed == ":::" {
+			hideDepth--
+			if hideDepth == 0 {
+				tokens = append(tokens, hideToken{
+					Kind:      "block",
+					Raw:       strings.Join(bodyLines, "\n"),
+					Attrs:     blockAttrs,
+					AttrOrder: blockAttrOrder,
+					Offset:    blockStart,
+					Length:    ln.start + len(ln.text) - blockStart,
+				})
+				// The closer line's own trailing newline (if any) is not
+				// part of the matched block span, same as the previous
+				// regexp (which stopped at the literal ":::").
+				if ln.nlLen == 1 {
+					tokens = append(tokens, hideToken{Kind: "text", Raw: "\n", Offset: ln.start + len(ln.text), Length: 1})
+				}
+			} else {
+				bodyLines = append(bodyLines, ln.text)
+			}
+			continue
+		}
+		if blockOpenerLineRe.MatchString(trimmed) {
+			hideDepth++
+		}
+		bodyLines = append(bodyLines, ln.text)
+	}
+
+	// An unterminated block (no matching closer) is left untouched, the
+	// same as the previous regexp-based parser would leave it unmatched.
+	if hideDepth > 0 {
+		tokens = append(tokens, hideToken{
+			Kind:   "text",
+			Raw:    content[blockStart:],
+			Offset: blockStart,
+			Length: len(content) - blockStart,
+		})
+	}
+
+	return tokens
+}
+
+// appendContentLine records line ln as either part of the current block's
+// body (when hideDepth > 0) or as a standalone passthrough text token.
+func appendContentLine(tokens []hideToken, ln rawLine, hideDepth int, bodyLines *[]string) []hideToken {
+	if hideDepth > 0 {
+		*bodyLines = append(*bodyLines, ln.text)
+		return tokens
+	}
+	return append(tokens, lineTextToken(ln))
+}
+
+func lineTextToken(ln rawLine) hideToken {
+	text := ln.text
+	if ln.nlLen == 1 {
+		text += "\n"
+	}
+	return hideToken{Kind: "text", Raw: text, Offset: ln.start, Length: len(text)}
+}
+
+// scanInlineLine finds every :::hide{...} ... ::: run in ln that is not
+// inside an inline code span, and splits the line into alternating text and
+// inline hideTokens.
+func scanInlineLine(ln rawLine) []hideToken {
+	text := ln.text
+	codeSpans := inlineCodeSpanRe.FindAllStringIndex(text, -1)
+
+	var tokens []hideToken
+	last := 0
+	for _, m := range inlineHidePatternRe.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[0], m[1]
+		if overlapsAny(start, end, codeSpans) {
+			continue
+		}
+		if start > last {
+			tokens = append(tokens, hideToken{Kind: "text", Raw: text[last:start], Offset: ln.start + last, Length: start - last})
+		}
+		attrs, attrOrder := parseHideAttrs(submatchString(text, m, 1))
+		tokens = append(tokens, hideToken{
+			Kind:      "inline",
+			Raw:       submatchString(text, m, 2),
+			Attrs:     attrs,
+			AttrOrder: attrOrder,
+			Offset:    ln.start + start,
+			Length:    end - start,
+		})
+		last = end
+	}
+	if last < len(text) {
+		tokens = append(tokens, hideToken{Kind: "text", Raw: text[last:], Offset: ln.start + last, Length: len(text) - last})
+	}
+	if ln.nlLen == 1 {
+		tokens = append(tokens, hideToken{Kind: "text", Raw: "\n", Offset: ln.start + len(text), Length: 1})
+	}
+	return tokens
+}
+
+func overlapsAny(start, end int, spans [][]int) bool {
+	for _, span := range spans {
+		if start < span[1] && end > span[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// detectFenceMarker reports whether trimmed opens or closes a fenced code
+// block: a line starting with a run of 3+ backticks or 3+ tildes. An
+// opening fence may carry a trailing info string (e.g. "```go"); a closing
+// fence is only required to repeat the marker at least as many times as the
+// opener (per CommonMark), which the caller checks against fenceLen.
+func detectFenceMarker(trimmed string) (ch byte, length int, ok bool) {
+	if len(trimmed) < 3 {
+		return 0, 0, false
+	}
+	c := trimmed[0]
+	if c != '`' && c != '~' {
+		return 0, 0, false
+	}
+	i := 0
+	for i < len(trimmed) && trimmed[i] == c {
+		i++
+	}
+	if i < 3 {
+		return 0, 0, false
+	}
+	return c, i, true
+}
+
+// rawLine is one line of content split out by splitRawLines, retaining its
+// byte offset so callers can compute absolute spans.
+type rawLine struct {
+	text  string // line text, excluding its trailing newline
+	start int    // byte offset of text[0] in the original content
+	nlLen int    // 1 if the line was followed by '\n', 0 for the final line
+}
+
+func splitRawLines(content string) []rawLine {
+	var lines []rawLine
+	start := 0
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			lines = append(lines, rawLine{text: content[start:i], start: start, nlLen: 1})
+			start = i + 1
+		}
+	}
+	lines = append(lines, rawLine{text: content[start:], start: start, nlLen: 0})
+	return lines
+}
@@ -0,0 +1,69 @@
+package forked
+
+import (
+	"testing"
+)
+
+func TestProcessHiddenContentCodeFence(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "hide block containing a code fence with a stray colon run",
+			input:    ":::hide\n```\n:::\n```\n:::",
+			expected: "[hide-block]",
+		},
+		{
+			name:     "nested hide block closes with the matching outer marker",
+			input:    ":::hide{level=high}\nouter start\n:::hide{level=low}\ninner\n:::\nouter end\n:::",
+			expected: "[hide-block{level:high}]",
+		},
+		{
+			name:     "inline hide inside an inline code span is left untouched",
+			input:    "Use `:::hide fake:::` literally",
+			expected: "Use `:::hide fake:::` literally",
+		},
+		{
+			name:     "hide block after a fenced code block outside it",
+			input:    "```\ncode here\n```\n:::hide secret:::\nend",
+			expected: "```\ncode here\n```\n[hide-inline]\nend",
+		},
+		{
+			name:     "block opener preceded by other text on the same line",
+			input:    "text:::hide\nSecret\n:::",
+			expected: "text[hide-block]",
+		},
+		{
+			name:     "block opener with attrs preceded by other text on the same line",
+			input:    "note: :::hide{level=high}\nSecret\n:::",
+			expected: "note: [hide-block{level:high}]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ProcessHiddenContent(tt.input)
+			if result != tt.expected {
+				t.Errorf("ProcessHiddenContent() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseHiddenRegionsNestedBlock(t *testing.T) {
+	content := ":::hide{level=high}\nouter start\n:::hide{level=low}\ninner\n:::\nouter end\n:::"
+	regions := ParseHiddenRegions(content)
+	if len(regions) != 1 {
+		t.Fatalf("ParseHiddenRegions() returned %d regions, want 1", len(regions))
+	}
+	r := regions[0]
+	if r.Kind != "block" {
+		t.Errorf("Kind = %q, want %q", r.Kind, "block")
+	}
+	wantRaw := "outer start\n:::hide{level=low}\ninner\n:::\nouter end"
+	if r.Raw != wantRaw {
+		t.Errorf("Raw = %q, want %q", r.Raw, wantRaw)
+	}
+}
@@ -0,0 +1,222 @@
+package forked
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Memo is the subset of memo fields this package's search criteria operate
+// on. Callers adapt their own memo representation into this shape.
+type Memo struct {
+	Name       string
+	CreatorID  int32
+	Content    string
+	Visibility string
+	Tags       []string
+	CreatedTs  int64 // unix seconds
+	LinkedTo   []string
+}
+
+// Polarity controls whether a Criterion must match (Include) or must not
+// match (Exclude) for MatchMemo to consider a memo a hit.
+type Polarity int
+
+const (
+	Include Polarity = iota
+	Exclude
+)
+
+// Criterion is one condition evaluated against a Memo by MatchMemo. Build
+// one with a constructor such as Name, Tag, or ContentSubstring, and wrap it
+// in Not to negate it.
+type Criterion struct {
+	Polarity Polarity
+	kind     string
+	match    func(Memo) bool
+}
+
+// Not negates c, turning a matching criterion into a non-matching one and
+// vice versa.
+func Not(c Criterion) Criterion {
+	if c.Polarity == Exclude {
+		c.Polarity = Include
+	} else {
+		c.Polarity = Exclude
+	}
+	return c
+}
+
+// Name matches memos whose Name contains substr.
+func Name(substr string) Criterion {
+	return Criterion{kind: "name", match: func(m Memo) bool {
+		return strings.Contains(m.Name, substr)
+	}}
+}
+
+// Tag matches memos carrying the given tag.
+func Tag(name string) Criterion {
+	return Criterion{kind: "tag", match: func(m Memo) bool {
+		for _, t := range m.Tags {
+			if t == name {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// Creator matches memos authored by creatorID.
+func Creator(creatorID int32) Criterion {
+	return Criterion{kind: "creator", match: func(m Memo) bool {
+		return m.CreatorID == creatorID
+	}}
+}
+
+// Visibility matches memos whose visibility is one of the given values.
+func Visibility(values ...string) Criterion {
+	return Criterion{kind: "visibility", match: func(m Memo) bool {
+		for _, v := range values {
+			if m.Visibility == v {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// ContentSubstring matches memos whose visible (placeholder-stripped)
+// content contains s.
+func ContentSubstring(s string) Criterion {
+	return Criterion{kind: "content_substring", match: func(m Memo) bool {
+		return strings.Contains(removeHiddenPlaceholders(m.Content), s)
+	}}
+}
+
+// ContentPattern matches memos whose visible (placeholder-stripped) content
+// matches the regular expression pattern. A pattern that fails to compile
+// never matches.
+func ContentPattern(pattern string) Criterion {
+	re, err := regexp.Compile(pattern)
+	return Criterion{kind: "content_pattern", match: func(m Memo) bool {
+		if err != nil {
+			return false
+		}
+		return re.MatchString(removeHiddenPlaceholders(m.Content))
+	}}
+}
+
+// HasHidden matches memos with at least one hidden region (a raw
+// :::hide::: block or an inline hide run) whose attributes satisfy attrs.
+func HasHidden(attrs func(attrs map[string]string) bool) Criterion {
+	return Criterion{kind: "has_hidden", match: func(m Memo) bool {
+		for _, region := range ParseHiddenRegions(m.Content) {
+			if region.Kind == "placeholder" {
+				continue
+			}
+			if attrs(region.Attrs) {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// DateRange matches memos created within [from, to].
+func DateRange(from, to time.Time) Criterion {
+	return Criterion{kind: "date_range", match: func(m Memo) bool {
+		created := time.Unix(m.CreatedTs, 0)
+		return !created.Before(from) && !created.After(to)
+	}}
+}
+
+// LinkTo matches memos that link to the memo named memoName.
+func LinkTo(memoName string) Criterion {
+	return Criterion{kind: "link_to", match: func(m Memo) bool {
+		for _, linked := range m.LinkedTo {
+			if linked == memoName {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// CriteriaFromFilters translates the content.contains(...) clauses of each
+// filter string accepted by ProcessContentSearchFilter into ContentSubstring
+// criteria, so a caller building a MatchMemo query can fold in existing
+// filter strings alongside structured criteria like Tag or DateRange.
+// Filters are a thin adapter over a single operator: other content
+// operators (startsWith, notContains, matches, ...) and boolean combinators
+// beyond && aren't represented here - use ParseFilter/Eval directly for the
+// full filter grammar. Rather than silently dropping a clause it can't
+// represent (which would weaken the resulting MatchMemo query instead of
+// enforcing it), CriteriaFromFilters returns an error naming the
+// unsupported filter.
+func CriteriaFromFilters(filters []string) ([]Criterion, error) {
+	var criteria []Criterion
+	for _, filter := range filters {
+		expr, err := ParseFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		c, err := contentSubstringCriteria(expr)
+		if err != nil {
+			return nil, fmt.Errorf("forked: filter %q: %w", filter, err)
+		}
+		criteria = append(criteria, c...)
+	}
+	return criteria, nil
+}
+
+// contentSubstringCriteria recurses through the && spine of expr (the only
+// combinator CriteriaFromFilters understands) collecting every
+// content.contains(...) clause as a ContentSubstring-equivalent criterion,
+// honoring its optional "i" (case-insensitive) argument the same way Eval
+// does. An UnknownExpr clause (a field other than content, e.g. creator_id)
+// contributes no criterion, same as Eval resolving it to true. Any other
+// clause - ||, !, or a content method besides contains - isn't
+// representable as a Criterion, and is reported as an error rather than
+// silently contributing nothing.
+func contentSubstringCriteria(expr Expr) ([]Criterion, error) {
+	switch e := expr.(type) {
+	case *AndExpr:
+		left, err := contentSubstringCriteria(e.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := contentSubstringCriteria(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		return append(left, right...), nil
+	case *CallExpr:
+		if e.Object == "content" && e.Method == "contains" && len(e.Args) > 0 {
+			args := e.Args
+			return []Criterion{{kind: "content_substring", match: func(m Memo) bool {
+				return matchSubstring(removeHiddenPlaceholders(m.Content), args, strings.Contains)
+			}}}, nil
+		}
+		return nil, fmt.Errorf("unsupported content method %q", e.Method)
+	case *UnknownExpr:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported filter clause %T", expr)
+	}
+}
+
+// MatchMemo reports whether memo satisfies every criterion, ANDed together,
+// with each criterion individually negated when its Polarity is Exclude.
+func MatchMemo(memo Memo, criteria []Criterion) bool {
+	for _, c := range criteria {
+		matched := c.match(memo)
+		if c.Polarity == Exclude {
+			matched = !matched
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+package forked
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCriteriaFromFilters(t *testing.T) {
+	memo := Memo{Content: "Hello world"}
+
+	criteria, err := CriteriaFromFilters([]string{
+		`creator_id == 1 && content.contains("Hello")`,
+		`content.contains("world")`,
+	})
+	if err != nil {
+		t.Fatalf("CriteriaFromFilters() error = %v, want nil", err)
+	}
+	if len(criteria) != 2 {
+		t.Fatalf("CriteriaFromFilters() returned %d criteria, want 2", len(criteria))
+	}
+	if !MatchMemo(memo, criteria) {
+		t.Errorf("MatchMemo() = false, want true for %q", memo.Content)
+	}
+
+	missing, err := CriteriaFromFilters([]string{`content.contains("goodbye")`})
+	if err != nil {
+		t.Fatalf("CriteriaFromFilters() error = %v, want nil", err)
+	}
+	if MatchMemo(memo, missing) {
+		t.Errorf("MatchMemo() = true, want false for a keyword that isn't present")
+	}
+
+	caseInsensitive, err := CriteriaFromFilters([]string{`content.contains("HELLO", "i")`})
+	if err != nil {
+		t.Fatalf("CriteriaFromFilters() error = %v, want nil", err)
+	}
+	if !MatchMemo(memo, caseInsensitive) {
+		t.Errorf("MatchMemo() = false, want true for a case-insensitive filter matching %q", memo.Content)
+	}
+}
+
+func TestCriteriaFromFiltersUnsupportedClause(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+	}{
+		{name: "|| combinator", filter: `content.contains("foo") || content.contains("bar")`},
+		{name: "! negation", filter: `!content.contains("foo")`},
+		{name: "notContains method", filter: `content.notContains("foo")`},
+		{name: "startsWith method", filter: `content.startsWith("foo")`},
+		{name: "endsWith method", filter: `content.endsWith("foo")`},
+		{name: "matches method", filter: `content.matches("/foo/")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			criteria, err := CriteriaFromFilters([]string{tt.filter})
+			if err == nil {
+				t.Fatalf("CriteriaFromFilters(%q) error = nil, want an error for an unsupported clause", tt.filter)
+			}
+			if criteria != nil {
+				t.Errorf("CriteriaFromFilters(%q) criteria = %v, want nil alongside the error", tt.filter, criteria)
+			}
+		})
+	}
+}
+
+func TestMatchMemo(t *testing.T) {
+	memo := Memo{
+		Name:       "weekly-standup",
+		CreatorID:  7,
+		Content:    "Plan for the week :::hide{level=high} budget numbers ::: done",
+		Visibility: "PRIVATE",
+		Tags:       []string{"work", "standup"},
+		CreatedTs:  time.Date(2026, 7, 20, 9, 0, 0, 0, time.UTC).Unix(),
+		LinkedTo:   []string{"project-roadmap"},
+	}
+
+	tests := []struct {
+		name     string
+		criteria []Criterion
+		expected bool
+	}{
+		{
+			name:     "single include matches",
+			criteria: []Criterion{Tag("work")},
+			expected: true,
+		},
+		{
+			name:     "single include fails",
+			criteria: []Criterion{Tag("personal")},
+			expected: false,
+		},
+		{
+			name:     "all criteria must match",
+			criteria: []Criterion{Creator(7), Visibility("PRIVATE"), ContentSubstring("Plan for the week")},
+			expected: true,
+		},
+		{
+			name:     "one failing criterion fails the whole match",
+			criteria: []Criterion{Creator(7), Visibility("PUBLIC")},
+			expected: false,
+		},
+		{
+			name:     "excluded criterion negates the match",
+			criteria: []Criterion{Tag("work"), Not(Tag("standup"))},
+			expected: false,
+		},
+		{
+			name:     "excluded criterion that doesn't match passes",
+			criteria: []Criterion{Tag("work"), Not(Tag("archived"))},
+			expected: true,
+		},
+		{
+			name:     "content substring matches visible text after a hidden region",
+			criteria: []Criterion{ContentSubstring("done")},
+			expected: true,
+		},
+		{
+			name:     "content pattern matches visible content",
+			criteria: []Criterion{ContentPattern("^Plan for")},
+			expected: true,
+		},
+		{
+			name:     "has hidden block with required level",
+			criteria: []Criterion{HasHidden(func(attrs map[string]string) bool { return attrs["level"] == "high" })},
+			expected: true,
+		},
+		{
+			name:     "has hidden block with unmatched level",
+			criteria: []Criterion{HasHidden(func(attrs map[string]string) bool { return attrs["level"] == "low" })},
+			expected: false,
+		},
+		{
+			name: "date range matches",
+			criteria: []Criterion{DateRange(
+				time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 7, 31, 0, 0, 0, 0, time.UTC),
+			)},
+			expected: true,
+		},
+		{
+			name: "date range excludes",
+			criteria: []Criterion{DateRange(
+				time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+			)},
+			expected: false,
+		},
+		{
+			name:     "link to matches",
+			criteria: []Criterion{LinkTo("project-roadmap")},
+			expected: true,
+		},
+		{
+			name: "combined creator, hidden level, and date range",
+			criteria: []Criterion{
+				Creator(7),
+				HasHidden(func(attrs map[string]string) bool { return attrs["level"] == "high" }),
+				DateRange(time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 31, 0, 0, 0, 0, time.UTC)),
+			},
+			expected: true,
+		},
+		{
+			name:     "no criteria always matches",
+			criteria: nil,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MatchMemo(memo, tt.criteria)
+			if result != tt.expected {
+				t.Errorf("MatchMemo() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}